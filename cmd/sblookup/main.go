@@ -18,6 +18,26 @@
 // the Safe Browsing API. The "Safe" or "Unsafe" verdict is printed to STDOUT.
 // If an error occurred, debug information may be printed to STDERR.
 //
+// In -crawl mode, each input line is instead treated as a seed URL: the
+// tool fetches the page, follows its links up to -depth hops, and checks
+// every URL discovered along the way. This is useful for auditing the
+// outbound links of a whole site rather than a pre-curated URL list.
+//
+// By default (-unwrap), URLs produced by known redirect/wrapper services
+// such as Microsoft Defender Safe Links or Google's click-tracking
+// redirect are unwrapped to their underlying target before lookup; see
+// the urlunwrap package for the list of supported services.
+//
+// The -output flag selects the report format: "text" (default), "json",
+// "ndjson", "csv", or "sarif" (for uploading as a GitHub code-scanning
+// step). See the report package for details of each format.
+//
+// -file (repeatable, globs allowed) and -dir (scanned recursively) read
+// input from files instead of STDIN, e.g. a directory of .eml/.txt/.html
+// files from a mailbox export. With -extract, each file is scanned for
+// URLs anywhere in its content instead of being read one URL per line;
+// results carry the source file and line/byte offset the URL came from.
+//
 // To build the tool:
 //	$ go get github.com/google/safebrowsing/cmd/sblookup
 //
@@ -33,22 +53,68 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/google/safebrowsing"
+	"github.com/google/safebrowsing/internal/crawl"
+	"github.com/google/safebrowsing/internal/extract"
+	"github.com/google/safebrowsing/report"
+	"github.com/google/safebrowsing/urlunwrap"
 )
 
 var (
 	apiKeyFlag   = flag.String("apikey", "", "specify your Safe Browsing API key")
 	databaseFlag = flag.String("db", "", "path to the Safe Browsing database. By default persistent storage is disabled (not recommended).")
+
+	crawlFlag       = flag.Bool("crawl", false, "treat input lines as seed URLs and crawl the pages they link to, looking up the seeds and every URL found")
+	depthFlag       = flag.Int("depth", 1, "maximum number of link hops to follow from each seed URL (only with -crawl)")
+	sameHostFlag    = flag.Bool("same-host", true, "restrict the crawl to links on the same host as their seed URL (only with -crawl)")
+	maxURLsFlag     = flag.Int("max-urls", 10000, "maximum number of URLs to visit across all seeds, or 0 for unlimited (only with -crawl)")
+	concurrencyFlag = flag.Int("concurrency", 10, "number of concurrent Safe Browsing lookup requests, and with -crawl also the number of pages to fetch concurrently")
+
+	unwrapFlag     = flag.Bool("unwrap", true, "recover the underlying target of known wrapper/redirect URLs (Safe Links, Google redirect, Proofpoint, Barracuda, ...) before lookup")
+	unwrapBothFlag = flag.Bool("unwrap-both", false, "when -unwrap recovers a target URL, also look up the original wrapper URL")
+
+	outputFlag = flag.String("output", "text", `output format: "text", "json", "ndjson", "csv", or "sarif"`)
+	formatFlag = flag.String("format", "", "alias for -output")
+
+	batchSizeFlag = flag.Int("batch-size", 500, "number of URLs to send per Safe Browsing API request, up to the v4 API limit")
+
+	fileFlag    stringsFlag
+	dirFlag     stringsFlag
+	extractFlag = flag.Bool("extract", false, "scan -file/-dir input for URLs instead of treating each line as one, using a regex for text and href/src attributes for HTML")
 )
 
+func init() {
+	flag.Var(&fileFlag, "file", "input file to read instead of STDIN (repeatable, glob patterns allowed)")
+	flag.Var(&fileFlag, "f", "alias for -file")
+	flag.Var(&dirFlag, "dir", "directory to read input files from instead of STDIN (repeatable, scanned recursively)")
+}
+
+// stringsFlag is a repeatable string flag: each -flag=value appends to the
+// slice instead of overwriting it.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
 const usage = `sblookup: command-line tool to lookup URLs with Safe Browsing.
 
 Tool reads one URL per line from STDIN and checks every URL against the
 Safe Browsing API. The Safe or Unsafe verdict is printed to STDOUT. If an error
 occurred, debug information may be printed to STDERR.
 
+With -crawl, each input line is a seed URL: the tool fetches the page,
+follows its links up to -depth hops, and checks the seeds together with
+every URL it finds.
+
 Exit codes:
   0     if all URLs were looked up an are safe.
   1     if at least one URL is not safe.
@@ -68,6 +134,15 @@ func main() {
 		fmt.Fprintln(os.Stderr, "No -apikey specified")
 		os.Exit(1)
 	}
+	format := *outputFlag
+	if *formatFlag != "" {
+		format = *formatFlag
+	}
+	rep, err := report.New(os.Stdout, format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	sb, err := safebrowsing.NewSafeBrowser(safebrowsing.Config{
 		APIKey: *apiKeyFlag,
 		DBPath: *databaseFlag,
@@ -78,31 +153,391 @@ func main() {
 		os.Exit(1)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	code := 0
-	for scanner.Scan() {
-		url := scanner.Text()
-		threats, err := sb.LookupURLs([]string{url})
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Lookup error:", err)
-			if code != 0 {
-				code = 128 // Invalid argument.
+	seeds, err := gatherSeeds()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to read input:", err)
+		os.Exit(128)
+	}
+
+	var code int
+	if *crawlFlag {
+		urls := make([]string, len(seeds))
+		for i, s := range seeds {
+			urls[i] = s.url
+		}
+		code = runCrawl(sb, rep, urls)
+	} else {
+		code = runLookup(sb, rep, seeds)
+	}
+	if err := rep.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to write report:", err)
+		os.Exit(128)
+	}
+	os.Exit(code)
+}
+
+// seedItem is one URL read from input, together with where it came from.
+// File is empty for URLs read from STDIN or passed directly as -crawl
+// seeds. Seed and Path are set only for URLs discovered via -crawl; File,
+// Line, and Offset are set only for URLs read from -file/-dir input;
+// Wrapper and WrapperURL are set only when -unwrap recovered url from a
+// known redirect/wrapper service.
+type seedItem struct {
+	url        string
+	file       string
+	line       int
+	offset     int64
+	seed       string
+	path       []string
+	wrapper    string
+	wrapperURL string
+}
+
+// urlJob is one URL to be looked up, tagged with its position in the
+// original input order so the collector can emit results in that order
+// even though they're looked up out of order across batches and workers.
+type urlJob struct {
+	seq int
+	seedItem
+}
+
+// record builds the report.Record for this job's outcome.
+func (j urlJob) record(err error, threats []safebrowsing.URLThreat) report.Record {
+	return report.Record{
+		URL:        j.url,
+		Threats:    threats,
+		Err:        err,
+		Seed:       j.seed,
+		Path:       j.path,
+		File:       j.file,
+		Line:       j.line,
+		Offset:     j.offset,
+		Wrapper:    j.wrapper,
+		WrapperURL: j.wrapperURL,
+	}
+}
+
+// batchResult is the outcome of looking up one batch of jobs together.
+type batchResult struct {
+	jobs    []urlJob
+	threats [][]safebrowsing.URLThreat // parallel to jobs, nil on err
+	err     error
+}
+
+// runBatchLookup checks every item against sb and writes one report.Record
+// per URL to rep, in the original input order. It is shared by both
+// plain lookups and -crawl, which differ only in how they produce items:
+// runLookup expands the raw seeds via -unwrap, and runCrawl expands every
+// URL discovered while walking the seeds' link graphs the same way.
+//
+// It pipelines the work in three stages connected by channels: a reader
+// groups items into urlJobs batched up to -batch-size URLs; a pool of
+// -concurrency workers looks up each batch with a single sb.LookupURLs
+// call (so the API's own batch-dedup and caching kick in); and a
+// collector reorders the resulting records back into input order before
+// handing them to rep. It returns the process exit code.
+func runBatchLookup(sb *safebrowsing.SafeBrowser, rep report.Reporter, items []seedItem) int {
+	batches := make(chan []urlJob)
+	go func() {
+		defer close(batches)
+		var batch []urlJob
+		seq := 0
+		flush := func() {
+			if len(batch) > 0 {
+				batches <- batch
+				batch = nil
+			}
+		}
+		for _, item := range items {
+			batch = append(batch, urlJob{seq: seq, seedItem: item})
+			seq++
+			if len(batch) >= *batchSizeFlag {
+				flush()
+			}
+		}
+		flush()
+	}()
+
+	results := make(chan batchResult)
+	var wg sync.WaitGroup
+	workers := *concurrencyFlag
+	if workers <= 0 {
+		workers = 1
+	}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				urls := make([]string, len(batch))
+				for k, j := range batch {
+					urls[k] = j.url
+				}
+				threats, err := sb.LookupURLs(urls)
+				results <- batchResult{jobs: batch, threats: threats, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		hadError, hadUnsafe bool
+		pending             = make(map[int]report.Record)
+		next                = 0
+	)
+	emit := func(seq int, rec report.Record) {
+		pending[seq] = rec
+		for r, ok := pending[next]; ok; r, ok = pending[next] {
+			delete(pending, next)
+			if err := rep.Add(r); err != nil {
+				fmt.Fprintln(os.Stderr, "Unable to write report:", err)
+				os.Exit(128)
+			}
+			next++
+		}
+	}
+	for br := range results {
+		if br.err != nil {
+			fmt.Fprintln(os.Stderr, "Lookup error:", br.err)
+			hadError = true
+			for _, j := range br.jobs {
+				emit(j.seq, j.record(br.err, nil))
+			}
+			continue
+		}
+		for i, j := range br.jobs {
+			rec := j.record(nil, br.threats[i])
+			if len(br.threats[i]) > 0 {
+				hadUnsafe = true
 			}
+			emit(j.seq, rec)
+		}
+	}
+
+	switch {
+	case hadError:
+		return 128
+	case hadUnsafe:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// runLookup expands every seed via the -unwrap/-unwrap-both flags and
+// checks the resulting URLs against sb through runBatchLookup.
+func runLookup(sb *safebrowsing.SafeBrowser, rep report.Reporter, seeds []seedItem) int {
+	var items []seedItem
+	for _, seed := range seeds {
+		for _, cu := range urlsToCheck(seed.url) {
+			item := seed
+			item.url = cu.url
+			item.wrapper = cu.wrapper
+			item.wrapperURL = cu.wrapperURL
+			items = append(items, item)
+		}
+	}
+	return runBatchLookup(sb, rep, items)
+}
+
+// checkURL is one URL to look up, together with the wrapper/redirect
+// service it was recovered from, if any (set by urlsToCheck).
+type checkURL struct {
+	url        string
+	wrapper    string
+	wrapperURL string
+}
+
+// urlsToCheck applies the -unwrap/-unwrap-both flags to a single input
+// URL, returning the URL(s) that should actually be looked up. When raw is
+// unwrapped, both the wrapper and the recovered target are logged to
+// STDERR, and the mapping is also attached to the returned checkURL(s) so
+// every output format (not just a human watching stderr) can report that
+// a URL was wrapped and by which service.
+func urlsToCheck(raw string) []checkURL {
+	if !*unwrapFlag {
+		return []checkURL{{url: raw}}
+	}
+	target, wrapper, err := urlunwrap.Unwrap(raw)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unwrap error:", err)
+		return []checkURL{{url: raw}}
+	}
+	if wrapper == "" {
+		return []checkURL{{url: raw}}
+	}
+	fmt.Fprintf(os.Stderr, "Unwrapped %s URL: %s -> %s\n", wrapper, raw, target)
+	unwrapped := checkURL{url: target, wrapper: wrapper, wrapperURL: raw}
+	if *unwrapBothFlag {
+		return []checkURL{unwrapped, {url: raw}}
+	}
+	return []checkURL{unwrapped}
+}
+
+// runCrawl expands each of seeds into the set of URLs reachable from it
+// (per the -depth/-same-host/-max-urls/-concurrency flags), expands each
+// discovered URL via -unwrap/-unwrap-both, and checks the results against
+// sb through runBatchLookup, which tags each
+// resulting report.Record with the seed it came from and the path of
+// pages that were followed to reach it. It returns the process exit code.
+func runCrawl(sb *safebrowsing.SafeBrowser, rep report.Reporter, seeds []string) int {
+	c := crawl.New(crawl.Config{
+		MaxDepth:      *depthFlag,
+		SameHostOnly:  *sameHostFlag,
+		MaxURLs:       *maxURLsFlag,
+		Concurrency:   *concurrencyFlag,
+		RespectRobots: true,
+	})
+
+	results, err := c.Walk(seeds)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Crawl error:", err)
+		return 128
+	}
+
+	var items []seedItem
+	for _, r := range results {
+		for _, cu := range urlsToCheck(r.URL) {
+			items = append(items, seedItem{url: cu.url, seed: r.Seed, path: r.Path, wrapper: cu.wrapper, wrapperURL: cu.wrapperURL})
 		}
-		if len(threats[0]) == 0 {
-			fmt.Fprintln(os.Stdout, "Safe URL:", url)
+	}
+	return runBatchLookup(sb, rep, items)
+}
+
+// gatherSeeds collects the URLs to process. With no -file/-dir flags it
+// reads one URL per line from STDIN, as before. Otherwise it resolves
+// -file (glob patterns allowed) and -dir (scanned recursively) into a list
+// of files and reads each one: in -extract mode every URL found anywhere
+// in the file is used; otherwise, as with STDIN, the file is read one
+// URL per line.
+func gatherSeeds() ([]seedItem, error) {
+	if len(fileFlag) == 0 && len(dirFlag) == 0 {
+		return readLines(os.Stdin, "")
+	}
+
+	paths, err := resolveInputFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var seeds []seedItem
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		var items []seedItem
+		if *extractFlag {
+			items, err = extractFile(f, path)
 		} else {
-			fmt.Fprintln(os.Stdout, "Unsafe URL:", threats[0])
-			if code != 0 {
-				code = 1
+			items, err = readLines(f, path)
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, items...)
+	}
+	return seeds, nil
+}
+
+// resolveInputFiles expands -file glob patterns and walks -dir directories
+// into a flat, de-duplicated list of paths: every -file match as is, and
+// every non-directory entry under each -dir.
+func resolveInputFiles() ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	for _, pattern := range fileFlag {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("-file %q: %w", pattern, err)
+		}
+		if matches == nil {
+			matches = []string{pattern} // not a glob: pass through so os.Open reports a clear error
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	for _, dir := range dirFlag {
+		err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
 			}
+			if !d.IsDir() {
+				add(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("-dir %q: %w", dir, err)
 		}
 	}
-	if scanner.Err() != nil {
-		fmt.Fprintln(os.Stderr, "Unable to read input:", scanner.Err())
-		if code != 0 {
-			code = 128 // Invalid argument.
+	return paths, nil
+}
+
+// readLines reads r one line per URL, as sblookup has always read STDIN.
+// file is attached to every seedItem (empty for STDIN).
+//
+// It reads with bufio.Reader.ReadString rather than bufio.Scanner so the
+// offset of each line reflects the actual bytes consumed, terminator
+// included. A Scanner's default split strips both "\r" and "\n", so
+// assuming a one-byte "\n" terminator (as a naive len(line)+1 tally would)
+// under-counts every line of a CRLF file — the norm for the Windows-
+// originated .eml/mailbox exports -file/-dir is meant to read.
+func readLines(r io.Reader, file string) ([]seedItem, error) {
+	var seeds []seedItem
+	br := bufio.NewReader(r)
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		raw, err := br.ReadString('\n')
+		if line := strings.TrimSpace(raw); line != "" {
+			item := seedItem{url: line, file: file}
+			if file != "" {
+				item.line, item.offset = lineNo, offset
+			}
+			seeds = append(seeds, item)
+		}
+		offset += int64(len(raw))
+		if err != nil {
+			if err == io.EOF {
+				return seeds, nil
+			}
+			return nil, err
 		}
 	}
-	os.Exit(code)
+}
+
+// extractFile scans the contents of f (whose path is used only to pick an
+// extractor and to label results) for URLs, using HTML-attribute-aware
+// extraction for .html/.htm files and a plain regex otherwise.
+func extractFile(f *os.File, path string) ([]seedItem, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []extract.Match
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		matches = extract.HTML(data)
+	default:
+		matches = extract.Text(data)
+	}
+
+	seeds := make([]seedItem, len(matches))
+	for i, m := range matches {
+		seeds[i] = seedItem{url: m.URL, file: path, line: m.Line, offset: m.Offset}
+	}
+	return seeds, nil
 }
\ No newline at end of file
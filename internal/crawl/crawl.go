@@ -0,0 +1,291 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crawl implements a small, bounded web crawler used to expand a
+// set of seed URLs into the set of URLs they link to. It is meant for
+// auditing a handful of sites (e.g. "does any page on this domain link to
+// something unsafe?"), not for general-purpose crawling: it keeps the
+// whole visited set in memory and has no persistence between runs.
+package crawl
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// Result is a single URL discovered during a crawl, together with the path
+// of pages that were followed to reach it starting from the seed.
+type Result struct {
+	// Seed is the original URL the crawl started from.
+	Seed string
+
+	// URL is the discovered (normalized, absolute) URL.
+	URL string
+
+	// Path is the chain of pages followed from Seed to URL, inclusive of
+	// both endpoints. len(Path) == 1 for the seed URL itself.
+	Path []string
+
+	// Depth is len(Path)-1.
+	Depth int
+}
+
+// Config controls the behavior of a Crawler.
+type Config struct {
+	// MaxDepth is the maximum number of link hops to follow from a seed
+	// URL. A MaxDepth of 0 only visits the seed URLs themselves.
+	MaxDepth int
+
+	// SameHostOnly restricts the crawl to links that share a host with
+	// the seed URL they were reached from.
+	SameHostOnly bool
+
+	// MaxURLs caps the total number of URLs visited across all seeds. A
+	// value <= 0 means unlimited.
+	MaxURLs int
+
+	// Concurrency is the number of pages fetched in parallel. It
+	// defaults to 1 if <= 0.
+	Concurrency int
+
+	// UserAgent is sent on every request. It defaults to a descriptive
+	// sblookup user agent if empty.
+	UserAgent string
+
+	// RespectRobots, when true, skips URLs disallowed by the target host's
+	// robots.txt. New does not change it, so the zero value is false.
+	RespectRobots bool
+
+	// Client is the HTTP client used to fetch pages and robots.txt. It
+	// defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+const defaultUserAgent = "sblookup-crawler/1.0 (+https://github.com/google/safebrowsing)"
+
+// Crawler walks the link graph rooted at a set of seed URLs.
+type Crawler struct {
+	cfg    Config
+	robots *robotsCache
+
+	mu      sync.Mutex
+	visited map[string]bool
+	count   int
+}
+
+// New creates a Crawler using cfg. Zero-valued fields in cfg are replaced
+// with sane defaults.
+func New(cfg Config) *Crawler {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaultUserAgent
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Crawler{
+		cfg:     cfg,
+		robots:  newRobotsCache(cfg.Client, cfg.UserAgent),
+		visited: make(map[string]bool),
+	}
+}
+
+// task is a unit of crawl work: visit url, having reached it via path from
+// a seed, at the given depth.
+type task struct {
+	seed  string
+	url   string
+	path  []string
+	depth int
+}
+
+// Walk crawls every seed and returns every URL discovered, including the
+// seeds themselves. Results are returned in the order they are
+// discovered, which is not guaranteed to be stable across runs.
+//
+// A fixed pool of Concurrency worker goroutines pulls tasks off a shared
+// queue; a worker that discovers child links pushes them back onto the
+// same queue rather than spawning its own goroutine to recurse, so the
+// number of concurrent fetches never exceeds Concurrency regardless of
+// crawl depth or branching factor.
+func (c *Crawler) Walk(seeds []string) ([]Result, error) {
+	var (
+		results []Result
+		resMu   sync.Mutex
+		q       = newWorkQueue()
+	)
+
+	// tryEnqueue normalizes and dedups t.url, records it as a Result, and
+	// — unless it's at max depth or disallowed by robots.txt — pushes it
+	// onto q for a worker to fetch and expand.
+	tryEnqueue := func(t task) {
+		norm, err := Normalize(t.url)
+		if err != nil {
+			return
+		}
+		t.url = norm
+
+		c.mu.Lock()
+		if c.visited[norm] || (c.cfg.MaxURLs > 0 && c.count >= c.cfg.MaxURLs) {
+			c.mu.Unlock()
+			return
+		}
+		c.visited[norm] = true
+		c.count++
+		c.mu.Unlock()
+
+		resMu.Lock()
+		results = append(results, Result{Seed: t.seed, URL: t.url, Path: t.path, Depth: t.depth})
+		resMu.Unlock()
+
+		if t.depth >= c.cfg.MaxDepth {
+			return
+		}
+		if c.cfg.RespectRobots && !c.robots.Allowed(t.url) {
+			return
+		}
+		q.push(t)
+	}
+
+	for _, s := range seeds {
+		tryEnqueue(task{seed: s, url: s, path: []string{s}, depth: 0})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(c.cfg.Concurrency)
+	for i := 0; i < c.cfg.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				t, ok := q.pop()
+				if !ok {
+					return
+				}
+				if links, err := c.fetchLinks(t.url); err == nil {
+					for _, l := range links {
+						if c.cfg.SameHostOnly && !sameHost(t.seed, l) {
+							continue
+						}
+						tryEnqueue(task{
+							seed:  t.seed,
+							url:   l,
+							path:  append(append([]string{}, t.path...), l),
+							depth: t.depth + 1,
+						})
+					}
+				}
+				q.done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// fetchLinks fetches rawURL and extracts every href/src link it contains,
+// resolved to absolute URLs.
+func (c *Crawler) fetchLinks(rawURL string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("crawl: %s: status %d", rawURL, resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if ct != "" && !strings.Contains(ct, "html") {
+		return nil, nil
+	}
+
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return extractLinks(resp.Body, base)
+}
+
+// extractLinks parses r as HTML and returns every href/src attribute value
+// resolved against base.
+func extractLinks(r io.Reader, base *url.URL) ([]string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" && attr.Key != "src" {
+					continue
+				}
+				ref, err := url.Parse(strings.TrimSpace(attr.Val))
+				if err != nil {
+					continue
+				}
+				links = append(links, base.ResolveReference(ref).String())
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return links, nil
+}
+
+// Normalize puts rawURL into a canonical form suitable for de-duplicating
+// the visited set: it lowercases the host, strips the fragment, and turns
+// an empty path into "/".
+func Normalize(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("crawl: not an absolute URL: %q", rawURL)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), nil
+}
+
+func sameHost(a, b string) bool {
+	ua, err1 := url.Parse(a)
+	ub, err2 := url.Parse(b)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Hostname(), ub.Hostname())
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crawl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "HTTP://Example.com/a#frag", want: "http://example.com/a"},
+		{in: "https://example.com", want: "https://example.com/"},
+		{in: "https://example.com/a/b?q=1#frag", want: "https://example.com/a/b?q=1"},
+		{in: "/relative/path", wantErr: true},
+		{in: "not a url at all", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := Normalize(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Normalize(%q) = %q, want error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Normalize(%q) returned error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestWalkBranchingDoesNotDeadlock crawls a tree of pages two links wide
+// at every depth, with Concurrency set below the number of pages that are
+// simultaneously in flight at depth >= 1. A worker pool that acquires a
+// fresh concurrency slot from inside an already-held slot (rather than
+// pushing child tasks onto a shared queue) deadlocks on exactly this
+// shape: every in-flight fetch blocks forever waiting for a slot that can
+// only free up once another in-flight fetch completes, and none can.
+func TestWalkBranchingDoesNotDeadlock(t *testing.T) {
+	var mux http.ServeMux
+	page := func(links ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body := ""
+			for _, l := range links {
+				body += fmt.Sprintf(`<a href="%s"></a>`, l)
+			}
+			w.Write([]byte(body))
+		}
+	}
+	mux.HandleFunc("/", page("/a", "/b"))
+	mux.HandleFunc("/a", page("/a1", "/a2"))
+	mux.HandleFunc("/b", page("/b1", "/b2"))
+	mux.HandleFunc("/a1", page())
+	mux.HandleFunc("/a2", page())
+	mux.HandleFunc("/b1", page())
+	mux.HandleFunc("/b2", page())
+	srv := httptest.NewServer(&mux)
+	defer srv.Close()
+
+	c := New(Config{
+		MaxDepth:      2,
+		SameHostOnly:  true,
+		Concurrency:   2,
+		RespectRobots: false,
+		Client:        srv.Client(),
+	})
+
+	done := make(chan []Result, 1)
+	go func() {
+		results, err := c.Walk([]string{srv.URL + "/"})
+		if err != nil {
+			t.Errorf("Walk returned error: %v", err)
+		}
+		done <- results
+	}()
+
+	select {
+	case results := <-done:
+		if len(results) != 7 {
+			t.Errorf("got %d results, want 7: %+v", len(results), results)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Walk deadlocked: did not complete within 10s")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crawl
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// robotsCache fetches and caches the Disallow rules that apply to the
+// crawler's user agent, keyed by host. It only implements the subset of
+// robots.txt needed to be a polite crawler: User-agent/Disallow pairs for
+// "*" and for our own user agent, with no wildcard or $ matching.
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	rules map[string][]string // host -> disallowed path prefixes
+}
+
+func newRobotsCache(client *http.Client, userAgent string) *robotsCache {
+	return &robotsCache{client: client, userAgent: userAgent, rules: make(map[string][]string)}
+}
+
+// Allowed reports whether rawURL may be fetched according to its host's
+// robots.txt. Hosts whose robots.txt cannot be fetched are treated as
+// allowing everything.
+func (c *robotsCache) Allowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+
+	c.mu.Lock()
+	rules, ok := c.rules[u.Host]
+	c.mu.Unlock()
+	if !ok {
+		rules = c.fetch(u)
+		c.mu.Lock()
+		c.rules[u.Host] = rules
+		c.mu.Unlock()
+	}
+
+	for _, prefix := range rules {
+		if strings.HasPrefix(u.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *robotsCache) fetch(u *url.URL) []string {
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequest(http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(resp.Body, c.userAgent)
+}
+
+// parseRobots extracts the Disallow rules that apply to userAgent (falling
+// back to the "*" group if there is no group specifically for it).
+func parseRobots(r interface{ Read([]byte) (int, error) }, userAgent string) []string {
+	scanner := bufio.NewScanner(r)
+
+	var (
+		star   []string
+		mine   []string
+		inStar bool
+		inMine bool
+		haveUA bool
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			inStar = value == "*"
+			inMine = strings.EqualFold(value, userAgent) || strings.Contains(strings.ToLower(userAgent), strings.ToLower(value))
+			if inMine {
+				haveUA = true
+			}
+		case "disallow":
+			if value == "" {
+				continue
+			}
+			if inStar {
+				star = append(star, value)
+			}
+			if inMine {
+				mine = append(mine, value)
+			}
+		}
+	}
+	if haveUA {
+		return mine
+	}
+	return star
+}
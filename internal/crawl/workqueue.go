@@ -0,0 +1,81 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crawl
+
+import "sync"
+
+// workQueue is an unbounded FIFO queue of crawl tasks, shared by a fixed
+// pool of worker goroutines. Unlike a buffered channel sized to
+// Concurrency, pushing new work here never blocks the pusher, so a worker
+// processing a task can enqueue that task's children without needing to
+// hold (or reacquire) a concurrency slot itself — there is exactly one
+// slot per worker goroutine, held for the worker's entire lifetime.
+//
+// pop blocks while the queue is empty but some task is still outstanding
+// (queued or being processed), and returns ok=false once every task ever
+// pushed has been popped and marked done with no queue left to drain,
+// signaling workers to exit.
+type workQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	items   []task
+	pending int // tasks queued or currently being processed
+}
+
+func newWorkQueue() *workQueue {
+	q := &workQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds t to the queue. It must be called once for every task that
+// will eventually be processed, including tasks pushed by a worker while
+// handling another task's children.
+func (q *workQueue) push(t task) {
+	q.mu.Lock()
+	q.pending++
+	q.items = append(q.items, t)
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop removes and returns the next task, blocking if none is immediately
+// available but more may still arrive. ok is false once the queue is
+// permanently drained.
+func (q *workQueue) pop() (t task, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return task{}, false
+	}
+	t, q.items = q.items[0], q.items[1:]
+	return t, true
+}
+
+// done marks one previously-popped task as fully processed (including any
+// children it pushed). Once pending reaches zero, every blocked pop wakes
+// up and returns ok=false.
+func (q *workQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	drained := q.pending == 0
+	q.mu.Unlock()
+	if drained {
+		q.cond.Broadcast()
+	}
+}
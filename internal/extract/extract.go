@@ -0,0 +1,131 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extract finds URLs embedded in arbitrary text or HTML, such as
+// a mailbox export, an .eml file, or a log file, and reports where in the
+// source each one was found.
+package extract
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Match is a single URL found in a file, together with where it was
+// found.
+type Match struct {
+	// URL is the extracted URL, exactly as it appeared in the source.
+	URL string
+
+	// Line is the 1-based line number the match starts on.
+	Line int
+
+	// Offset is the 0-based byte offset of the match within the file.
+	Offset int64
+}
+
+// urlRE matches bare http(s) URLs in free text. It stops at whitespace,
+// quotes, angle brackets, and closing parentheses/brackets so that URLs
+// embedded in prose or markup aren't swallowed along with their
+// surrounding characters. Other trailing punctuation, such as a period or
+// comma, is kept as part of the URL.
+var urlRE = regexp.MustCompile(`https?://[^\s"'<>)\]]+`)
+
+// attrRE matches href="..." and src='...' attribute values in HTML, which
+// catches URLs that urlRE would miss because they're split across markup
+// (e.g. entity-encoded). It also matches relative values; of its matches,
+// HTML keeps only absolute (http/https) ones.
+var attrRE = regexp.MustCompile(`(?i)(?:href|src)\s*=\s*["']([^"']+)["']`)
+
+// Text extracts every URL in data using a plain regex scan. This is the
+// right choice for .txt/.eml files and any other free-form text.
+func Text(data []byte) []Match {
+	return findAll(data, urlRE)
+}
+
+// HTML extracts every URL in data, combining Text's regex scan with a
+// pass over href/src attribute values. Relative attribute values (no
+// http/https scheme) are skipped, since there is no base URL to resolve
+// them against when scanning a file in isolation.
+func HTML(data []byte) []Match {
+	matches := Text(data)
+	for _, m := range findAllSubmatch(data, attrRE) {
+		if strings.HasPrefix(m.URL, "http://") || strings.HasPrefix(m.URL, "https://") {
+			matches = append(matches, m)
+		}
+	}
+	return dedup(matches)
+}
+
+func findAll(data []byte, re *regexp.Regexp) []Match {
+	var matches []Match
+	line := 1
+	lineStart := 0
+	for _, idx := range re.FindAllIndex(data, -1) {
+		line, lineStart = advanceLine(data, lineStart, line, idx[0])
+		matches = append(matches, Match{
+			URL:    string(data[idx[0]:idx[1]]),
+			Line:   line,
+			Offset: int64(idx[0]),
+		})
+	}
+	return matches
+}
+
+func findAllSubmatch(data []byte, re *regexp.Regexp) []Match {
+	var matches []Match
+	line := 1
+	lineStart := 0
+	for _, idx := range re.FindAllSubmatchIndex(data, -1) {
+		start, end := idx[2], idx[3] // capture group 1
+		line, lineStart = advanceLine(data, lineStart, line, start)
+		matches = append(matches, Match{
+			URL:    string(data[start:end]),
+			Line:   line,
+			Offset: int64(start),
+		})
+	}
+	return matches
+}
+
+// advanceLine counts newlines between the last position the caller
+// checked (lineStart, on line) and pos, returning the updated line number
+// and the byte offset of the start of that line. Matches are visited in
+// increasing offset order, so this runs in a single linear pass instead
+// of rescanning from the top of the file for every match.
+func advanceLine(data []byte, lineStart, line int, pos int) (int, int) {
+	for {
+		nl := bytes.IndexByte(data[lineStart:pos], '\n')
+		if nl < 0 {
+			return line, lineStart
+		}
+		line++
+		lineStart += nl + 1
+	}
+}
+
+func dedup(matches []Match) []Match {
+	seen := make(map[string]bool, len(matches))
+	out := matches[:0]
+	for _, m := range matches {
+		key := m.URL
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, m)
+	}
+	return out
+}
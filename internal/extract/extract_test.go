@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestText(t *testing.T) {
+	data := []byte("see http://example.com/a for details\nalso check https://example.com/b?q=1 today\n")
+	got := Text(data)
+	want := []Match{
+		{URL: "http://example.com/a", Line: 1, Offset: 4},
+		{URL: "https://example.com/b?q=1", Line: 2, Offset: 48},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Text(%q) = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestTextStopsAtTrailingPunctuation(t *testing.T) {
+	data := []byte(`(see "http://example.com/a", and <http://example.com/b>)`)
+	got := Text(data)
+	want := []Match{
+		{URL: "http://example.com/a", Line: 1, Offset: 6},
+		{URL: "http://example.com/b", Line: 1, Offset: 34},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Text(%q) = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestTextLineCountingAcrossMultipleLines(t *testing.T) {
+	data := []byte("line one\nline two\nline three has http://example.com/c here\n")
+	got := Text(data)
+	want := []Match{
+		{URL: "http://example.com/c", Line: 3, Offset: 33},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Text(%q) = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	data := []byte(`<a href="http://example.com/a">link</a><img src='https://example.com/b.png'>`)
+	got := HTML(data)
+	want := []Match{
+		{URL: "http://example.com/a", Line: 1, Offset: 9},
+		{URL: "https://example.com/b.png", Line: 1, Offset: 49},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HTML(%q) = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestHTMLSkipsRelativeAttributeValues(t *testing.T) {
+	data := []byte(`<a href="/relative/path">link</a><a href="http://example.com/a">abs</a>`)
+	got := HTML(data)
+	want := []Match{
+		{URL: "http://example.com/a", Line: 1, Offset: 42},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HTML(%q) = %+v, want %+v", data, got, want)
+	}
+}
+
+func TestHTMLDedupesRepeatedURL(t *testing.T) {
+	data := []byte(`see http://example.com/a and again <a href="http://example.com/a">here</a>`)
+	got := HTML(data)
+	want := []Match{
+		{URL: "http://example.com/a", Line: 1, Offset: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HTML(%q) = %+v, want %+v (the href occurrence should be dropped as a duplicate)", data, got, want)
+	}
+}
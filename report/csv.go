@@ -0,0 +1,79 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+var csvHeader = []string{"url", "seed", "path", "file", "line", "offset", "wrapper", "wrapper_url", "safe", "threat_type", "platform_type", "threat_entry_type", "error"}
+
+// csvReporter emits one row per URL, or one row per (URL, threat) pair
+// when a URL matches more than one threat. Safe URLs and lookup errors
+// get a single row with the threat_* columns left blank.
+type csvReporter struct {
+	w  io.Writer
+	cw *csv.Writer
+}
+
+func (r *csvReporter) Add(rec Record) error {
+	if r.cw == nil {
+		r.cw = csv.NewWriter(r.w)
+		if err := r.cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	path := ""
+	for i, p := range rec.Path {
+		if i > 0 {
+			path += " -> "
+		}
+		path += p
+	}
+	line, offset := "", ""
+	if rec.File != "" {
+		line = strconv.Itoa(rec.Line)
+		offset = strconv.FormatInt(rec.Offset, 10)
+	}
+	errMsg := ""
+	if rec.Err != nil {
+		errMsg = rec.Err.Error()
+	}
+
+	if len(rec.Threats) == 0 {
+		return r.cw.Write([]string{rec.URL, rec.Seed, path, rec.File, line, offset, rec.Wrapper, rec.WrapperURL, strconv.FormatBool(rec.Safe()), "", "", "", errMsg})
+	}
+	for _, t := range rec.Threats {
+		row := []string{
+			rec.URL, rec.Seed, path, rec.File, line, offset, rec.Wrapper, rec.WrapperURL, strconv.FormatBool(rec.Safe()),
+			t.ThreatType.String(), t.PlatformType.String(), t.ThreatEntryType.String(), errMsg,
+		}
+		if err := r.cw.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *csvReporter) Close() error {
+	if r.cw == nil {
+		return nil
+	}
+	r.cw.Flush()
+	return r.cw.Error()
+}
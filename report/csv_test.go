@@ -0,0 +1,142 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/safebrowsing"
+)
+
+func readCSV(t *testing.T, buf *bytes.Buffer) [][]string {
+	t.Helper()
+	rows, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV output: %v", err)
+	}
+	return rows
+}
+
+func TestCSVReporterSafeURL(t *testing.T) {
+	var buf bytes.Buffer
+	r := &csvReporter{w: &buf}
+	if err := r.Add(Record{URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rows := readCSV(t, &buf)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2 (header + 1): %v", len(rows), rows)
+	}
+	if !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Errorf("header = %v, want %v", rows[0], csvHeader)
+	}
+	want := []string{"https://example.com/a", "", "", "", "", "", "", "", "true", "", "", "", ""}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("row = %v, want %v", rows[1], want)
+	}
+}
+
+func TestCSVReporterError(t *testing.T) {
+	var buf bytes.Buffer
+	r := &csvReporter{w: &buf}
+	if err := r.Add(Record{URL: "https://example.com/a", Err: errors.New("lookup failed")}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	r.Close()
+
+	rows := readCSV(t, &buf)
+	row := rows[1]
+	if got := row[8]; got != "false" {
+		t.Errorf("safe column = %q, want %q", got, "false")
+	}
+	if got := row[len(row)-1]; got != "lookup failed" {
+		t.Errorf("error column = %q, want %q", got, "lookup failed")
+	}
+}
+
+// TestCSVReporterFileSeedWrapperColumns verifies the seed/path, file/line/
+// offset, and wrapper/wrapper_url columns are all populated and correctly
+// escaped (the second crawl path element contains a comma, so the joined
+// path column must be quoted properly by encoding/csv).
+func TestCSVReporterFileSeedWrapperColumns(t *testing.T) {
+	var buf bytes.Buffer
+	r := &csvReporter{w: &buf}
+	rec := Record{
+		URL:        "https://example.com/a",
+		Seed:       "https://example.com/",
+		Path:       []string{"https://example.com/", "https://example.com/a,b"},
+		File:       "mail.eml",
+		Line:       42,
+		Offset:     1024,
+		Wrapper:    "safelinks",
+		WrapperURL: "https://foo.safelinks.protection.outlook.com/?url=https%3A%2F%2Fexample.com%2Fa",
+	}
+	if err := r.Add(rec); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	r.Close()
+
+	rows := readCSV(t, &buf)
+	row := rows[1]
+	want := []string{
+		"https://example.com/a",
+		"https://example.com/",
+		"https://example.com/ -> https://example.com/a,b",
+		"mail.eml",
+		"42",
+		"1024",
+		"safelinks",
+		"https://foo.safelinks.protection.outlook.com/?url=https%3A%2F%2Fexample.com%2Fa",
+		"true",
+		"", "", "", "",
+	}
+	if !reflect.DeepEqual(row, want) {
+		t.Errorf("row = %v, want %v", row, want)
+	}
+}
+
+func TestCSVReporterOneRowPerThreat(t *testing.T) {
+	var buf bytes.Buffer
+	r := &csvReporter{w: &buf}
+	rec := Record{
+		URL:     "https://bad.example",
+		Threats: []safebrowsing.URLThreat{{}, {}},
+	}
+	if err := r.Add(rec); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	r.Close()
+
+	rows := readCSV(t, &buf)
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3 (header + one per threat): %v", len(rows), rows)
+	}
+	for _, row := range rows[1:] {
+		if row[0] != rec.URL {
+			t.Errorf("row url = %q, want %q", row[0], rec.URL)
+		}
+		if row[8] != "false" {
+			t.Errorf("safe column = %q, want %q (URL has threats)", row[8], "false")
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonReporter buffers every Record and emits a single JSON array on
+// Close.
+type jsonReporter struct {
+	w       io.Writer
+	records []Record
+}
+
+func (r *jsonReporter) Add(rec Record) error {
+	if rec.Err != nil {
+		rec.ErrMessage = rec.Err.Error()
+	}
+	r.records = append(r.records, rec)
+	return nil
+}
+
+func (r *jsonReporter) Close() error {
+	records := r.records
+	if records == nil {
+		records = []Record{} // encode as [] rather than null
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// ndjsonReporter emits one JSON object per Record, per line, as it is
+// added. Unlike jsonReporter this never buffers, so it is suitable for
+// piping into jq or a log pipeline while sblookup is still running.
+type ndjsonReporter struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (r *ndjsonReporter) Add(rec Record) error {
+	if rec.Err != nil {
+		rec.ErrMessage = rec.Err.Error()
+	}
+	if r.enc == nil {
+		r.enc = json.NewEncoder(r.w)
+	}
+	return r.enc.Encode(rec)
+}
+
+func (r *ndjsonReporter) Close() error {
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONReporterOmitsEmptyFields(t *testing.T) {
+	var buf bytes.Buffer
+	r := &jsonReporter{w: &buf}
+	if err := r.Add(Record{URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("unmarshaling output: %v\noutput: %s", err, buf.String())
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	for _, field := range []string{"threats", "error", "seed", "path", "file", "line", "offset", "wrapper", "wrapper_url"} {
+		if _, ok := records[0][field]; ok {
+			t.Errorf("record has %q field, want it omitted for an empty value: %v", field, records[0])
+		}
+	}
+	if records[0]["url"] != "https://example.com/a" {
+		t.Errorf("url = %v, want %q", records[0]["url"], "https://example.com/a")
+	}
+}
+
+func TestJSONReporterSetsErrorMessageFromErr(t *testing.T) {
+	var buf bytes.Buffer
+	r := &jsonReporter{w: &buf}
+	if err := r.Add(Record{URL: "https://example.com/a", Err: errors.New("lookup failed")}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	r.Close()
+
+	out := buf.String()
+	if !strings.Contains(out, `"error": "lookup failed"`) {
+		t.Errorf("output missing error message, got: %s", out)
+	}
+}
+
+func TestNDJSONReporterOneLinePerRecord(t *testing.T) {
+	var buf bytes.Buffer
+	r := &ndjsonReporter{w: &buf}
+	if err := r.Add(Record{URL: "https://example.com/a"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Add(Record{URL: "https://example.com/b"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, want := range []string{"https://example.com/a", "https://example.com/b"} {
+		var rec Record
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatalf("unmarshaling line %d: %v", i, err)
+		}
+		if rec.URL != want {
+			t.Errorf("line %d url = %q, want %q", i, rec.URL, want)
+		}
+	}
+}
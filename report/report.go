@@ -0,0 +1,104 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report formats Safe Browsing lookup results for output. It is
+// shared by every tool that prints lookup verdicts (currently sblookup)
+// so that adding a new tool, or a new output format, doesn't mean
+// reimplementing the others.
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/google/safebrowsing"
+)
+
+// Record is a single URL's lookup result.
+type Record struct {
+	// URL is the URL that was checked.
+	URL string `json:"url"`
+
+	// Threats holds the matched threats, if any. An empty/nil Threats
+	// means the URL is considered safe.
+	Threats []safebrowsing.URLThreat `json:"threats,omitempty"`
+
+	// Err is set if the lookup itself failed (as opposed to succeeding
+	// with an unsafe verdict).
+	Err error `json:"-"`
+
+	// ErrMessage mirrors Err as a string for the JSON and NDJSON formats,
+	// which cannot encode an error value directly; their reporters set it
+	// from Err.
+	ErrMessage string `json:"error,omitempty"`
+
+	// Seed and Path are populated in crawl mode: Seed is the original
+	// seed URL and Path is the chain of pages followed from it to URL.
+	Seed string   `json:"seed,omitempty"`
+	Path []string `json:"path,omitempty"`
+
+	// Wrapper and WrapperURL are populated when URL was recovered from a
+	// known redirect/wrapper service (-unwrap): Wrapper is the decoder
+	// name (e.g. "safelinks") and WrapperURL is the original wrapped URL
+	// that was unwrapped to produce URL.
+	Wrapper    string `json:"wrapper,omitempty"`
+	WrapperURL string `json:"wrapper_url,omitempty"`
+
+	// File, Line, and Offset are populated when URL was extracted from a
+	// file (-file/-dir/-extract): File is the path it came from, and
+	// Line/Offset locate the match within that file.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Offset int64  `json:"offset,omitempty"`
+}
+
+// Safe reports whether r represents a clean lookup with no matched
+// threats.
+func (r Record) Safe() bool {
+	return r.Err == nil && len(r.Threats) == 0
+}
+
+// A Reporter accepts Records as they become available and writes them out
+// in some format. Streaming formats (text, ndjson, csv) write each Record
+// as it is added; batched formats (json, sarif) buffer until Close.
+// Either way, callers must call Close to guarantee all output has been
+// flushed to w.
+type Reporter interface {
+	// Add records one lookup result.
+	Add(Record) error
+
+	// Close finalizes the output (e.g. closing a JSON array) and flushes
+	// any buffered writes. It must be called exactly once, after the
+	// last Add.
+	Close() error
+}
+
+// New returns a Reporter that writes to w in the given format. Supported
+// formats are "text", "json", "ndjson", "csv", and "sarif".
+func New(w io.Writer, format string) (Reporter, error) {
+	switch format {
+	case "", "text":
+		return &textReporter{w: w}, nil
+	case "json":
+		return &jsonReporter{w: w}, nil
+	case "ndjson":
+		return &ndjsonReporter{w: w}, nil
+	case "csv":
+		return &csvReporter{w: w}, nil
+	case "sarif":
+		return &sarifReporter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("report: unknown format %q", format)
+	}
+}
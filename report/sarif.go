@@ -0,0 +1,155 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SARIF (Static Analysis Results Interchange Format) schema version this
+// reporter emits. Only the subset of the schema needed to upload URL
+// findings as GitHub code-scanning results is populated.
+const sarifSchema = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+const sarifRuleUnsafeURL = "unsafe-url"
+
+// sarifReporter buffers every Record and emits them as a single SARIF log
+// on Close, with one result per matched threat and one warning result per
+// failed lookup. It is meant to be
+// uploaded as a GitHub code-scanning SARIF file from a CI step that scans
+// URLs found in repo content.
+type sarifReporter struct {
+	w       io.Writer
+	results []sarifResult
+}
+
+func (r *sarifReporter) Add(rec Record) error {
+	if rec.Err != nil {
+		r.results = append(r.results, sarifResult{
+			RuleID:    sarifRuleUnsafeURL,
+			Level:     "warning",
+			Message:   sarifMessage{Text: fmt.Sprintf("lookup failed for %s: %v%s", rec.URL, rec.Err, rec.wrapperSuffix())},
+			Locations: rec.sarifLocations(),
+		})
+		return nil
+	}
+	for _, t := range rec.Threats {
+		r.results = append(r.results, sarifResult{
+			RuleID: sarifRuleUnsafeURL,
+			Level:  "error",
+			Message: sarifMessage{Text: fmt.Sprintf("%s is flagged as %s (%s, %s)%s",
+				rec.URL, t.ThreatType, t.PlatformType, t.ThreatEntryType, rec.wrapperSuffix())},
+			Locations: rec.sarifLocations(),
+		})
+	}
+	return nil
+}
+
+// wrapperSuffix notes the wrapper URL a result was recovered from, if any.
+func (rec Record) wrapperSuffix() string {
+	if rec.Wrapper == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (unwrapped from %s via %s)", rec.WrapperURL, rec.Wrapper)
+}
+
+// sarifLocations points a SARIF result at the file/line a URL was
+// extracted from, if known, falling back to the URL itself as the
+// artifact so every result still has a location.
+func (rec Record) sarifLocations() []sarifLocation {
+	if rec.File == "" {
+		return []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: rec.URL},
+		}}}
+	}
+	return []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: rec.File},
+		Region:           &sarifRegion{StartLine: rec.Line},
+	}}}
+}
+
+func (r *sarifReporter) Close() error {
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "sblookup",
+				InformationURI: "https://github.com/google/safebrowsing",
+				Rules:          []sarifRule{{ID: sarifRuleUnsafeURL, Name: "UnsafeURL"}},
+			}},
+			Results: r.results,
+		}},
+	}
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// textReporter reproduces sblookup's original "Safe URL: ..." / "Unsafe
+// URL: ..." output, one line per Record.
+type textReporter struct {
+	w io.Writer
+}
+
+func (r *textReporter) Add(rec Record) error {
+	suffix := ""
+	if rec.Seed != "" {
+		suffix += fmt.Sprintf(" (seed %s, path %v)", rec.Seed, rec.Path)
+	}
+	if rec.File != "" {
+		suffix += fmt.Sprintf(" (%s:%d)", rec.File, rec.Line)
+	}
+	if rec.Wrapper != "" {
+		suffix += fmt.Sprintf(" (unwrapped from %s via %s)", rec.WrapperURL, rec.Wrapper)
+	}
+	switch {
+	case rec.Err != nil:
+		_, err := fmt.Fprintf(r.w, "Lookup error: %s: %v%s\n", rec.URL, rec.Err, suffix)
+		return err
+	case rec.Safe():
+		_, err := fmt.Fprintf(r.w, "Safe URL: %s%s\n", rec.URL, suffix)
+		return err
+	default:
+		_, err := fmt.Fprintf(r.w, "Unsafe URL: %v%s\n", rec.Threats, suffix)
+		return err
+	}
+}
+
+func (r *textReporter) Close() error {
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package urlunwrap
+
+import "testing"
+
+func TestUnwrap(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantWrapper string
+		wantTarget  string
+		wantErr     bool
+	}{
+		{
+			name:        "safelinks",
+			raw:         "https://foo-bar.safelinks.protection.outlook.com/?url=https%3A%2F%2Fexample.com%2Fa&data=01",
+			wantWrapper: "safelinks",
+			wantTarget:  "https://example.com/a",
+		},
+		{
+			name:        "google redirect www subdomain",
+			raw:         "https://www.google.com/url?q=https%3A%2F%2Fexample.com%2Fa",
+			wantWrapper: "google-redirect",
+			wantTarget:  "https://example.com/a",
+		},
+		{
+			name:    "google redirect lookalike domain is not unwrapped",
+			raw:     "https://www.notgoogle.com/url?q=https%3A%2F%2Fevil.example%2Fa",
+			wantErr: false,
+			// No decoder matches, so the lookalike host's own URL is what
+			// gets checked rather than the attacker-controlled q= target.
+			wantTarget: "https://www.notgoogle.com/url?q=https%3A%2F%2Fevil.example%2Fa",
+		},
+		{
+			name:        "proofpoint v1",
+			raw:         "https://urldefense.proofpoint.com/v1/url?u=https://example.com/a&k=1&r=2",
+			wantWrapper: "proofpoint-v1",
+			wantTarget:  "https://example.com/a",
+		},
+		{
+			name:        "proofpoint v2",
+			raw:         "https://urldefense.proofpoint.com/v2/url?u=https-3A__example.com_a_b-3Fq-3D1",
+			wantWrapper: "proofpoint-v2",
+			wantTarget:  "https://example.com/a/b?q=1",
+		},
+		{
+			name:        "proofpoint v3",
+			raw:         "https://urldefense.com/v3/__https-3A__example.com_a_b-3Fq-3D1__;!!abc!def$",
+			wantWrapper: "proofpoint-v3",
+			wantTarget:  "https://example.com/a/b?q=1",
+		},
+		{
+			name:    "proofpoint v3 lookalike domain is not unwrapped",
+			raw:     "https://evilurldefense.com/v3/__https-3A__evil.example_a__;!!abc!def$",
+			wantErr: false,
+			// No decoder matches, so the lookalike host's own URL is what
+			// gets checked rather than the attacker-controlled path target.
+			wantTarget: "https://evilurldefense.com/v3/__https-3A__evil.example_a__;!!abc!def$",
+		},
+		{
+			name:        "barracuda",
+			raw:         "https://linkprotect.cudasvc.com/url?a=https%3A%2F%2Fexample.com%2Fa&c=1&h=2",
+			wantWrapper: "barracuda",
+			wantTarget:  "https://example.com/a",
+		},
+		{
+			name:       "unrecognized host is returned unchanged",
+			raw:        "https://example.com/a",
+			wantTarget: "https://example.com/a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, wrapper, err := Unwrap(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("Unwrap(%q) = %q, nil, want error", tc.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unwrap(%q) returned error: %v", tc.raw, err)
+			}
+			if wrapper != tc.wantWrapper {
+				t.Errorf("Unwrap(%q) wrapper = %q, want %q", tc.raw, wrapper, tc.wantWrapper)
+			}
+			if got != tc.wantTarget {
+				t.Errorf("Unwrap(%q) = %q, want %q", tc.raw, got, tc.wantTarget)
+			}
+		})
+	}
+}
+
+func TestDecodeProofpointEncoding(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "https-3A__example.com_a_b-3Fq-3D1", want: "https://example.com/a/b?q=1"},
+		{in: "example.com_path", want: "example.com/path"},
+		{in: "truncated-4", wantErr: true},
+		{in: "bad-escape-zz_rest", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := decodeProofpointEncoding(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("decodeProofpointEncoding(%q) = %q, want error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("decodeProofpointEncoding(%q) returned error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("decodeProofpointEncoding(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package urlunwrap recovers the underlying target of URLs rewritten by
+// mail security gateways and link-tracking services, such as Microsoft
+// Defender Safe Links or Google's click-tracking redirect. This matters
+// for Safe Browsing lookups: checking the wrapper URL only ever tells you
+// the wrapping service is safe, not the page it actually points at.
+package urlunwrap
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// A Decoder recognizes and unwraps URLs from one specific wrapping
+// service.
+type Decoder struct {
+	// Name identifies the wrapping service, e.g. "safelinks".
+	Name string
+
+	// Matches reports whether u was produced by this Decoder's service.
+	Matches func(u *url.URL) bool
+
+	// Decode extracts the original target URL from u. It is only called
+	// when Matches(u) is true.
+	Decode func(u *url.URL) (string, error)
+}
+
+var (
+	mu       sync.RWMutex
+	registry []Decoder
+)
+
+// Register adds d to the set of decoders consulted by Unwrap. Decoders
+// are tried in registration order, and the first match wins, so callers
+// adding their own decoders should Register them before relying on
+// precedence against another decoder for the same host.
+func Register(d Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry = append(registry, d)
+}
+
+// Unwrap inspects raw and, if it was produced by a known wrapping
+// service, returns the original target URL and the name of the service
+// that wrapped it. If raw does not match any registered Decoder, original
+// is raw itself and wrapper is "".
+func Unwrap(raw string) (original string, wrapper string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw, "", fmt.Errorf("urlunwrap: %w", err)
+	}
+
+	mu.RLock()
+	decoders := make([]Decoder, len(registry))
+	copy(decoders, registry)
+	mu.RUnlock()
+
+	for _, d := range decoders {
+		if !d.Matches(u) {
+			continue
+		}
+		orig, err := d.Decode(u)
+		if err != nil {
+			return raw, d.Name, fmt.Errorf("urlunwrap: %s: %w", d.Name, err)
+		}
+		return orig, d.Name, nil
+	}
+	return raw, "", nil
+}